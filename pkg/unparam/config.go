@@ -0,0 +1,234 @@
+// Copyright (c) 2017, Daniel Martí <mvdan@mvdan.cc>
+// See LICENSE for licensing information
+
+package unparam
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"gopkg.in/yaml.v3"
+)
+
+// Rules holds the exclusion and severity settings loaded from a
+// .unparam.yml or unparam.json config file, the same way
+// honnef.co/go/tools' config package layers staticcheck.conf files. The
+// zero value excludes nothing and leaves every check enabled.
+type Rules struct {
+	// Exclude lists regex patterns matched against a flagged
+	// function's full name, "pkg.Func" or "(pkg.Type).Method"; any
+	// match is skipped entirely, with no diagnostic at all.
+	Exclude []string `json:"exclude" yaml:"exclude"`
+
+	// AllowUnused lists, per package import path, parameter names that
+	// are never reported even when otherwise unused, e.g. "ctx" or "t".
+	AllowUnused map[string][]string `json:"allowUnused" yaml:"allowUnused"`
+
+	// Checks toggles the optional sub-checks by name
+	// (always-const-arg, unused-result, dummy-impl-detection,
+	// unused-result-skip-error). Anything left unset defaults to
+	// enabled.
+	Checks map[string]bool `json:"checks" yaml:"checks"`
+
+	// HarmlessCall, if set, replaces the default regex dummyImpl uses
+	// to recognise logging/printing calls inside an otherwise-trivial
+	// function body.
+	HarmlessCall string `json:"harmlessCall" yaml:"harmlessCall"`
+
+	exclude      []*regexp.Regexp
+	harmlessCall *regexp.Regexp
+}
+
+// Rule IDs, used both as Rules.Checks keys and, via the Category field
+// on reported diagnostics, as //lint:ignore unparam:<id> targets.
+const (
+	checkAlwaysConstArg = "always-const-arg"
+	checkUnusedResult   = "unused-result"
+	checkDummyImpl      = "dummy-impl-detection"
+
+	// checkSkipErrorResult toggles whether the unused-result check
+	// ignores error-typed results, since discarding an error is
+	// intentionally common in Go. Enabled by default.
+	checkSkipErrorResult = "unused-result-skip-error"
+)
+
+var configNames = []string{".unparam.yml", ".unparam.yaml", "unparam.json"}
+
+// loadRules walks from dir upward to the filesystem root, merging every
+// config file it finds along the way; settings closer to dir win.
+func loadRules(dir string) (*Rules, error) {
+	var files []string
+	for {
+		for _, name := range configNames {
+			p := filepath.Join(dir, name)
+			if _, err := os.Stat(p); err == nil {
+				files = append(files, p)
+				break
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	merged := &Rules{}
+	for i := len(files) - 1; i >= 0; i-- { // root-most first, so dir-most wins
+		r, err := parseRulesFile(files[i])
+		if err != nil {
+			return nil, err
+		}
+		merged.merge(r)
+	}
+	merged.compile()
+	return merged, nil
+}
+
+func parseRulesFile(path string) (*Rules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var r Rules
+	if strings.HasSuffix(path, ".json") {
+		err = json.Unmarshal(data, &r)
+	} else {
+		err = yaml.Unmarshal(data, &r)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &r, nil
+}
+
+func (r *Rules) merge(other *Rules) {
+	r.Exclude = append(r.Exclude, other.Exclude...)
+	for pkg, names := range other.AllowUnused {
+		if r.AllowUnused == nil {
+			r.AllowUnused = make(map[string][]string)
+		}
+		r.AllowUnused[pkg] = append(r.AllowUnused[pkg], names...)
+	}
+	for check, enabled := range other.Checks {
+		if r.Checks == nil {
+			r.Checks = make(map[string]bool)
+		}
+		r.Checks[check] = enabled
+	}
+	if other.HarmlessCall != "" {
+		r.HarmlessCall = other.HarmlessCall
+	}
+}
+
+func (r *Rules) compile() {
+	for _, pat := range r.Exclude {
+		rx, err := regexp.Compile(pat)
+		if err != nil {
+			continue
+		}
+		r.exclude = append(r.exclude, rx)
+	}
+	if r.HarmlessCall != "" {
+		if rx, err := regexp.Compile(r.HarmlessCall); err == nil {
+			r.harmlessCall = rx
+		}
+	}
+}
+
+func (r *Rules) excluded(fullName string) bool {
+	if r == nil {
+		return false
+	}
+	for _, rx := range r.exclude {
+		if rx.MatchString(fullName) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rules) allowsUnused(pkgPath, name string) bool {
+	if r == nil {
+		return false
+	}
+	for _, n := range r.AllowUnused[pkgPath] {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rules) checkEnabled(name string) bool {
+	if r == nil {
+		return true
+	}
+	enabled, ok := r.Checks[name]
+	if !ok {
+		return true
+	}
+	return enabled
+}
+
+func (r *Rules) harmlessCallRegex() *regexp.Regexp {
+	if r != nil && r.harmlessCall != nil {
+		return r.harmlessCall
+	}
+	return rxHarmlessCall
+}
+
+// cwdConfig loads the config file hierarchy rooted at the process's
+// working directory. It's used by the whole-program CLI entry points
+// (Fix, ConstArgs, UnusedResults), which only ever run once per process
+// against a single working directory.
+func cwdConfig() (*Rules, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	return loadRules(wd)
+}
+
+// passConfig loads the config file hierarchy rooted at the directory of
+// the package pass is analyzing. Unlike cwdConfig, it can't assume a
+// single working directory for the whole run: the Analyzer can be
+// embedded in a long-lived process (golangci-lint, gopls) that analyzes
+// many packages, in many directories, one pass at a time.
+func passConfig(pass *analysis.Pass) (*Rules, error) {
+	if len(pass.Files) == 0 {
+		return &Rules{}, nil
+	}
+	dir := filepath.Dir(pass.Fset.Position(pass.Files[0].Pos()).Filename)
+	return loadRules(dir)
+}
+
+// rxLintIgnore matches a staticcheck-style suppression comment:
+// //lint:ignore unparam,unparam:always-const-arg reason text
+var rxLintIgnore = regexp.MustCompile(`^//lint:ignore\s+(\S+)`)
+
+// lintIgnored reports whether doc carries a //lint:ignore comment
+// naming rule.
+func lintIgnored(doc *ast.CommentGroup, rule string) bool {
+	if doc == nil {
+		return false
+	}
+	for _, c := range doc.List {
+		m := rxLintIgnore.FindStringSubmatch(c.Text)
+		if m == nil {
+			continue
+		}
+		for _, name := range strings.Split(m[1], ",") {
+			if name == rule {
+				return true
+			}
+		}
+	}
+	return false
+}
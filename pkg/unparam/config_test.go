@@ -0,0 +1,66 @@
+// Copyright (c) 2017, Daniel Martí <mvdan@mvdan.cc>
+// See LICENSE for licensing information
+
+package unparam
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadRulesMerge(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "sub")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(path, content string) {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(filepath.Join(root, ".unparam.yml"), "exclude:\n  - pkg.Root\nchecks:\n  always-const-arg: false\n")
+	write(filepath.Join(sub, ".unparam.yml"), "exclude:\n  - pkg.Sub\n")
+
+	rules, err := loadRules(sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rules.excluded("pkg.Root") {
+		t.Error("expected pkg.Root to be excluded by the root config")
+	}
+	if !rules.excluded("pkg.Sub") {
+		t.Error("expected pkg.Sub to be excluded by the sub config")
+	}
+	if rules.checkEnabled(checkAlwaysConstArg) {
+		t.Error("expected always-const-arg to be disabled by the root config")
+	}
+	if !rules.checkEnabled(checkUnusedResult) {
+		t.Error("expected unused-result to default to enabled")
+	}
+}
+
+func TestAllowsUnused(t *testing.T) {
+	r := &Rules{AllowUnused: map[string][]string{"pkg": {"ctx"}}}
+	if !r.allowsUnused("pkg", "ctx") {
+		t.Error("expected ctx to be allowed in pkg")
+	}
+	if r.allowsUnused("pkg", "other") {
+		t.Error("other should not be allowed in pkg")
+	}
+	if r.allowsUnused("otherpkg", "ctx") {
+		t.Error("ctx should not be allowed outside pkg")
+	}
+}
+
+func TestCheckSkipErrorResultDefault(t *testing.T) {
+	var r *Rules
+	if !r.checkEnabled(checkSkipErrorResult) {
+		t.Error("expected unused-result-skip-error to default to enabled")
+	}
+	r = &Rules{Checks: map[string]bool{checkSkipErrorResult: false}}
+	if r.checkEnabled(checkSkipErrorResult) {
+		t.Error("expected unused-result-skip-error to be disabled once toggled off")
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2017, Daniel Martí <mvdan@mvdan.cc>
+// See LICENSE for licensing information
+
+package unparam
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"sort"
+
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/callgraph/cha"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+// ConstArgs reports parameters that, although used inside their
+// function's body, are given the identical constant argument at every
+// reachable call site. Such a parameter could just as well be inlined
+// as that constant, which makes it a useful complement to the
+// unused-parameter check: one finds parameters nobody reads, the other
+// finds parameters nobody varies.
+func ConstArgs(cfg Config, args ...string) ([]string, error) {
+	wp, err := cfg.loadWholeProgram(args...)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := cwdConfig()
+	if err != nil {
+		return nil, err
+	}
+	if !rules.checkEnabled(checkAlwaysConstArg) {
+		return nil, nil
+	}
+	funcSigns := computeFuncSigns(wp.prog)
+	cg := cha.CallGraph(wp.prog)
+	cg.DeleteSyntheticNodes()
+
+	var warns []string
+	for fn := range ssautil.AllFunctions(wp.prog) {
+		if fn.Pkg == nil || !wp.wantPkg[fn.Pkg.Pkg] {
+			continue
+		}
+		if len(fn.Blocks) == 0 {
+			continue
+		}
+		if funcSigns[signString(fn.Signature)] { // required shape; callers can't be changed freely
+			continue
+		}
+		if fn.Object() != nil && fn.Object().Exported() {
+			continue // exported API: callers outside this program are invisible to us
+		}
+		if rules.excluded(fullFuncName(fn)) {
+			continue
+		}
+		if decl, ok := fn.Syntax().(*ast.FuncDecl); ok && lintIgnored(decl.Doc, "unparam:"+checkAlwaysConstArg) {
+			continue
+		}
+		node := cg.Nodes[fn]
+		if node == nil {
+			continue
+		}
+		for i, par := range fn.Params {
+			if i == 0 && fn.Signature.Recv() != nil { // receiver
+				continue
+			}
+			if len(*par.Referrers()) == 0 {
+				continue // already reported as unused
+			}
+			if _, ok := par.Type().Underlying().(*types.Interface); ok {
+				continue // different concrete values could still share one interface constant
+			}
+			cnst, ok := sameConstArg(node, i)
+			if !ok {
+				continue
+			}
+			pos := wp.fset.Position(par.Pos())
+			warns = append(warns, fmt.Sprintf("%s: parameter %s always receives %s",
+				pos, par.Name(), cnst.String()))
+		}
+	}
+	sort.Strings(warns)
+	return warns, nil
+}
+
+// sameConstArg reports the constant passed for parameter argIdx if
+// every incoming call edge to node passes that same constant, and there
+// is at least one such call.
+func sameConstArg(node *callgraph.Node, argIdx int) (*ssa.Const, bool) {
+	var found *ssa.Const
+	for _, edge := range node.In {
+		site := edge.Site
+		if site == nil { // synthetic root edge
+			continue
+		}
+		callArgs := site.Common().Args
+		if argIdx >= len(callArgs) {
+			return nil, false
+		}
+		cnst, ok := callArgs[argIdx].(*ssa.Const)
+		if !ok {
+			return nil, false
+		}
+		if found == nil {
+			found = cnst
+			continue
+		}
+		if !sameConst(found, cnst) {
+			return nil, false
+		}
+	}
+	if found == nil {
+		return nil, false
+	}
+	return found, true
+}
+
+func sameConst(a, b *ssa.Const) bool {
+	if !types.Identical(a.Type(), b.Type()) {
+		return false
+	}
+	if a.IsNil() || b.IsNil() {
+		return a.IsNil() == b.IsNil()
+	}
+	return a.Value.String() == b.Value.String()
+}
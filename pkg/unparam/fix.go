@@ -0,0 +1,265 @@
+// Copyright (c) 2017, Daniel Martí <mvdan@mvdan.cc>
+// See LICENSE for licensing information
+
+package unparam
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"go/types"
+	"os"
+	"sort"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+// Fix finds unused parameters across the whole program built from args,
+// using the same whole-program SSA build the original unparam used
+// before it was ported to go/analysis, then rewrites every function
+// declaration and call site it can edit safely. It returns the paths of
+// the files it changed.
+//
+// Unlike the per-package SuggestedFix that Analyzer attaches to its
+// diagnostics, Fix sees the entire program at once, so it can follow a
+// call from one package into a function declared in another. Any
+// parameter for which it finds an unsafe call site (a side-effecting
+// argument, a variadic slot, or a function literal it can't trace
+// callers for) is left alone rather than partially rewritten.
+func Fix(cfg Config, args ...string) ([]string, error) {
+	wp, err := cfg.loadWholeProgram(args...)
+	if err != nil {
+		return nil, err
+	}
+	prog, fset := wp.prog, wp.fset
+
+	rules, err := cwdConfig()
+	if err != nil {
+		return nil, err
+	}
+	funcSigns := computeFuncSigns(prog)
+	fileIdx := buildFileIndex(fset, wp.files)
+
+	edits := make(map[*ast.File][]analysis.TextEdit)
+	escapes := make(map[types.Object]bool)
+	for _, c := range wholeProgramCandidates(prog, wp.wantPkg, funcSigns, rules) {
+		if c.flatIdx < 0 {
+			continue
+		}
+		if c.fn.Signature.Variadic() && c.flatIdx == c.fn.Signature.Params().Len()-1 {
+			continue
+		}
+		decl, ok := c.fn.Syntax().(*ast.FuncDecl)
+		if !ok {
+			continue // func literal: its callers can't be traced reliably
+		}
+		obj := c.fn.Object()
+		if _, cached := escapes[obj]; !cached {
+			escapes[obj] = funcEscapesAsValue(wp.files, func(f *ast.File) *types.Info { return wp.fileInfo[f] }, obj)
+		}
+		if escapes[obj] {
+			continue // a variable/field/method value could hide call sites we can't find
+		}
+		declEdit, ok := deleteParam(decl.Type.Params, c.flatIdx)
+		if !ok {
+			continue
+		}
+		callEdits, ok := wholeProgramCallSiteEdits(prog, fset, fileIdx, c.fn, c.flatIdx)
+		if !ok {
+			continue
+		}
+		declFile := fileIdx[fset.File(decl.Pos())]
+		edits[declFile] = append(edits[declFile], declEdit)
+		for f, es := range callEdits {
+			edits[f] = append(edits[f], es...)
+		}
+	}
+
+	var changed []string
+	for file, es := range edits {
+		name := fset.Position(file.Pos()).Filename
+		src, err := os.ReadFile(name)
+		if err != nil {
+			return changed, err
+		}
+		out := applyEdits(fset, src, es)
+		out, err = format.Source(out)
+		if err != nil {
+			return changed, fmt.Errorf("%s: %w", name, err)
+		}
+		if err := os.WriteFile(name, out, 0o644); err != nil {
+			return changed, err
+		}
+		changed = append(changed, name)
+	}
+	sort.Strings(changed)
+	return changed, nil
+}
+
+type candidate struct {
+	fn      *ssa.Function
+	flatIdx int
+	par     *ssa.Parameter
+}
+
+// wholeProgramCandidates reports the same unused parameters that
+// Analyzer would report, across every package in wantPkg at once.
+func wholeProgramCandidates(prog *ssa.Program, wantPkg map[*types.Package]bool, funcSigns map[string]bool, rules *Rules) []candidate {
+	var out []candidate
+	for fn := range ssautil.AllFunctions(prog) {
+		if fn.Pkg == nil || !wantPkg[fn.Pkg.Pkg] {
+			continue
+		}
+		if len(fn.Blocks) == 0 || isDummyImpl(rules, fn.Blocks[0]) {
+			continue
+		}
+		if funcSigns[signString(fn.Signature)] {
+			continue
+		}
+		if rules.excluded(fullFuncName(fn)) {
+			continue
+		}
+		if fn.Object() != nil && fn.Object().Exported() {
+			continue // exported API: callers outside this program are invisible to us
+		}
+		for i, par := range fn.Params {
+			if i == 0 && fn.Signature.Recv() != nil {
+				continue
+			}
+			switch par.Object().Name() {
+			case "", "_":
+				continue
+			}
+			if len(*par.Referrers()) > 0 {
+				continue
+			}
+			if rules.allowsUnused(fn.Pkg.Pkg.Path(), par.Name()) {
+				continue
+			}
+			out = append(out, candidate{fn: fn, flatIdx: flatParamIndex(fn, par), par: par})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].par.Pos() < out[j].par.Pos()
+	})
+	return out
+}
+
+// wholeProgramCallSiteEdits finds every static call to target across
+// the whole program and returns the edits needed to drop its flatIdx'th
+// argument, grouped by the file each edit belongs to. It reports
+// ok=false, with no edits, if any such call can't be rewritten safely.
+func wholeProgramCallSiteEdits(prog *ssa.Program, fset *token.FileSet, fileIdx map[*token.File]*ast.File, target *ssa.Function, flatIdx int) (map[*ast.File][]analysis.TextEdit, bool) {
+	edits := make(map[*ast.File][]analysis.TextEdit)
+	for fn := range ssautil.AllFunctions(prog) {
+		for _, blk := range fn.Blocks {
+			for _, instr := range blk.Instrs {
+				call, ok := instr.(*ssa.Call)
+				if !ok || call.Call.StaticCallee() != target {
+					continue
+				}
+				file := fileIdx[fset.File(call.Pos())]
+				if file == nil {
+					continue // synthetic call with no source to edit
+				}
+				path, _ := astutil.PathEnclosingInterval(file, call.Pos(), call.Pos())
+				ce := enclosingCall(path)
+				if ce == nil || flatIdx >= len(ce.Args) {
+					return nil, false
+				}
+				if hasSideEffects(ce.Args[flatIdx]) {
+					return nil, false
+				}
+				starts := make([]token.Pos, len(ce.Args))
+				ends := make([]token.Pos, len(ce.Args))
+				for i, a := range ce.Args {
+					starts[i], ends[i] = a.Pos(), a.End()
+				}
+				edits[file] = append(edits[file], deleteListItem(starts, ends, flatIdx))
+			}
+		}
+	}
+	return edits, true
+}
+
+func enclosingCall(path []ast.Node) *ast.CallExpr {
+	for _, n := range path {
+		if ce, ok := n.(*ast.CallExpr); ok {
+			return ce
+		}
+	}
+	return nil
+}
+
+// computeFuncSigns is the whole-program counterpart of
+// collectFuncSigns: it walks every loaded package directly, rather than
+// merging per-package facts, since Fix already has the whole program in
+// hand.
+func computeFuncSigns(prog *ssa.Program) map[string]bool {
+	funcSigns := make(map[string]bool)
+	addSign := func(t types.Type) {
+		sign, ok := t.(*types.Signature)
+		if !ok || sign.Params().Len() == 0 {
+			return
+		}
+		funcSigns[signString(sign)] = true
+	}
+	for _, pkg := range prog.AllPackages() {
+		for _, mb := range pkg.Members {
+			switch mb.Token() {
+			case token.FUNC:
+				params := mb.Type().(*types.Signature).Params()
+				for i := 0; i < params.Len(); i++ {
+					addSign(params.At(i).Type())
+				}
+				continue
+			case token.TYPE:
+			default:
+				continue
+			}
+			switch x := mb.Type().Underlying().(type) {
+			case *types.Struct:
+				for i := 0; i < x.NumFields(); i++ {
+					addSign(x.Field(i).Type())
+				}
+			case *types.Interface:
+				for i := 0; i < x.NumMethods(); i++ {
+					addSign(x.Method(i).Type())
+				}
+			case *types.Signature:
+				addSign(x)
+			}
+		}
+	}
+	return funcSigns
+}
+
+func buildFileIndex(fset *token.FileSet, files []*ast.File) map[*token.File]*ast.File {
+	idx := make(map[*token.File]*ast.File, len(files))
+	for _, f := range files {
+		idx[fset.File(f.Pos())] = f
+	}
+	return idx
+}
+
+// applyEdits rewrites src by applying edits, which must be positions
+// within src as recorded in fset.
+func applyEdits(fset *token.FileSet, src []byte, edits []analysis.TextEdit) []byte {
+	sort.Slice(edits, func(i, j int) bool { return edits[i].Pos < edits[j].Pos })
+	var out bytes.Buffer
+	offset := 0
+	for _, e := range edits {
+		start := fset.Position(e.Pos).Offset
+		end := fset.Position(e.End).Offset
+		out.Write(src[offset:start])
+		out.Write(e.NewText)
+		offset = end
+	}
+	out.Write(src[offset:])
+	return out.Bytes()
+}
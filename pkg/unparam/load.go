@@ -0,0 +1,92 @@
+// Copyright (c) 2017, Daniel Martí <mvdan@mvdan.cc>
+// See LICENSE for licensing information
+
+package unparam
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+// Config controls how a whole-program build loads its packages. The
+// zero value loads the named packages, and everything they depend on,
+// the same way `go build` would: honoring GOFLAGS, build tags, vendor
+// directories and go.work files alike, since it's backed by
+// golang.org/x/tools/go/packages rather than the older go/loader.
+type Config struct {
+	// Tests also loads each package's test binary variant, so that
+	// parameters or results only exercised from _test.go files aren't
+	// misreported.
+	Tests bool
+	// BuildFlags are passed through to the underlying `go list`
+	// invocation, e.g. []string{"-tags", "integration"}.
+	BuildFlags []string
+	// Overlay replaces the named files' contents with the given data,
+	// letting editors and CI drive the checker over unsaved buffers.
+	Overlay map[string][]byte
+}
+
+// wholeProgram is the whole-program SSA build shared by the checks that
+// need to see every call site at once, rather than one package at a
+// time: Fix, and the always-const-arg and unused-result passes.
+type wholeProgram struct {
+	prog    *ssa.Program
+	fset    *token.FileSet
+	files   []*ast.File
+	wantPkg map[*types.Package]bool
+	// fileInfo maps each file in files to the types.Info of the
+	// package it belongs to, so callers can resolve identifiers back
+	// to objects without re-deriving a file-to-package mapping.
+	fileInfo map[*ast.File]*types.Info
+}
+
+func (c Config) loadWholeProgram(args ...string) (*wholeProgram, error) {
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedImports |
+			packages.NeedDeps | packages.NeedTypes | packages.NeedSyntax |
+			packages.NeedTypesInfo,
+		Tests:      c.Tests,
+		BuildFlags: c.BuildFlags,
+		Overlay:    c.Overlay,
+	}
+	initial, err := packages.Load(cfg, args...)
+	if err != nil {
+		return nil, err
+	}
+	if packages.PrintErrors(initial) > 0 {
+		return nil, fmt.Errorf("unparam: errors loading packages")
+	}
+
+	wantPkg := make(map[*types.Package]bool, len(initial))
+	for _, pkg := range initial {
+		wantPkg[pkg.Types] = true
+	}
+
+	var fset *token.FileSet
+	var files []*ast.File
+	fileInfo := make(map[*ast.File]*types.Info)
+	seen := make(map[*packages.Package]bool)
+	packages.Visit(initial, func(pkg *packages.Package) bool {
+		if seen[pkg] {
+			return false
+		}
+		seen[pkg] = true
+		fset = pkg.Fset
+		files = append(files, pkg.Syntax...)
+		for _, f := range pkg.Syntax {
+			fileInfo[f] = pkg.TypesInfo
+		}
+		return true
+	}, nil)
+
+	prog, _ := ssautil.AllPackages(initial, ssa.GlobalDebug)
+	prog.Build()
+
+	return &wholeProgram{prog: prog, fset: fset, files: files, wantPkg: wantPkg, fileInfo: fileInfo}, nil
+}
@@ -0,0 +1,279 @@
+// Copyright (c) 2017, Daniel Martí <mvdan@mvdan.cc>
+// See LICENSE for licensing information
+
+package unparam
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/ssa"
+)
+
+// paramFix builds a SuggestedFix that deletes par from its function's
+// declaration and from every call site paramFix can find within the
+// package being analyzed. It returns nil, leaving the finding as a
+// plain diagnostic, whenever it can't be sure the rewrite is safe: a
+// variadic parameter, a function literal (whose call sites can't be
+// traced by name), or a call site whose matching argument might have a
+// side effect that the program relies on.
+//
+// Cross-package call sites aren't rewritten here, since a single
+// package's pass can't edit another package's files; that's handled by
+// the whole-program -fix CLI mode instead.
+func paramFix(pass *analysis.Pass, par *ssa.Parameter) *analysis.SuggestedFix {
+	fn := par.Parent()
+	flatIdx := flatParamIndex(fn, par)
+	if flatIdx < 0 {
+		return nil
+	}
+	if fn.Signature.Variadic() && flatIdx == fn.Signature.Params().Len()-1 {
+		return nil
+	}
+	decl := funcDecl(pass, fn)
+	if decl == nil {
+		return nil // func literal; callers can't be traced by name
+	}
+	declEdit, ok := deleteParam(decl.Type.Params, flatIdx)
+	if !ok {
+		return nil
+	}
+	callEdits, ok := callSiteEdits(pass, decl, flatIdx)
+	if !ok {
+		return nil
+	}
+	edits := append([]analysis.TextEdit{declEdit}, callEdits...)
+	return &analysis.SuggestedFix{
+		Message:   "Remove unused parameter " + par.Name(),
+		TextEdits: edits,
+	}
+}
+
+// funcDecl locates fn's *ast.FuncDecl among the files of the package
+// being analyzed. fn.Syntax() can't be used for this: buildssa builds
+// without ssa.GlobalDebug, so it doesn't reliably point back at the
+// declaration's AST node.
+func funcDecl(pass *analysis.Pass, fn *ssa.Function) *ast.FuncDecl {
+	tf := pass.Fset.File(fn.Pos())
+	if tf == nil {
+		return nil
+	}
+	for _, file := range pass.Files {
+		if pass.Fset.File(file.Pos()) != tf {
+			continue
+		}
+		path, _ := astutil.PathEnclosingInterval(file, fn.Pos(), fn.Pos())
+		for _, n := range path {
+			if decl, ok := n.(*ast.FuncDecl); ok {
+				return decl
+			}
+		}
+	}
+	return nil
+}
+
+// flatParamIndex returns the index of par within fn's declared
+// parameter list, with any method receiver excluded, or -1 if par
+// isn't one of fn's parameters.
+func flatParamIndex(fn *ssa.Function, par *ssa.Parameter) int {
+	off := 0
+	if fn.Signature.Recv() != nil {
+		off = 1
+	}
+	for i, p := range fn.Params {
+		if i < off {
+			continue
+		}
+		if p == par {
+			return i - off
+		}
+	}
+	return -1
+}
+
+// deleteParam returns the edit needed to remove the flatIdx'th
+// parameter from fl, merging it with a neighbouring comma so the list
+// stays well-formed.
+func deleteParam(fl *ast.FieldList, flatIdx int) (analysis.TextEdit, bool) {
+	i := 0
+	for fi, f := range fl.List {
+		n := len(f.Names)
+		if n == 0 {
+			n = 1
+		}
+		if flatIdx >= i+n {
+			i += n
+			continue
+		}
+		if len(f.Names) <= 1 {
+			starts := make([]token.Pos, len(fl.List))
+			ends := make([]token.Pos, len(fl.List))
+			for j, field := range fl.List {
+				starts[j], ends[j] = field.Pos(), field.End()
+			}
+			return deleteListItem(starts, ends, fi), true
+		}
+		starts := make([]token.Pos, len(f.Names))
+		ends := make([]token.Pos, len(f.Names))
+		for j, name := range f.Names {
+			starts[j], ends[j] = name.Pos(), name.End()
+		}
+		return deleteListItem(starts, ends, flatIdx-i), true
+	}
+	return analysis.TextEdit{}, false
+}
+
+// callSiteEdits finds every call to decl within the files of the
+// package being analyzed and returns the edits needed to drop the
+// flatIdx'th argument from each. It reports ok=false if any such
+// argument might have a side effect, in which case no edit is safe.
+func callSiteEdits(pass *analysis.Pass, decl *ast.FuncDecl, flatIdx int) ([]analysis.TextEdit, bool) {
+	obj := pass.TypesInfo.Defs[decl.Name]
+	if obj == nil {
+		return nil, false
+	}
+	if funcEscapesAsValue(pass.Files, func(*ast.File) *types.Info { return pass.TypesInfo }, obj) {
+		// obj's identity is captured somewhere (a variable, field, or
+		// method value) rather than only ever called directly by name,
+		// so a call reached through that value would be left with a
+		// stale argument list.
+		return nil, false
+	}
+	var edits []analysis.TextEdit
+	safe := true
+	for _, file := range pass.Files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			if !safe {
+				return false
+			}
+			call, ok := n.(*ast.CallExpr)
+			if !ok || !callsFunc(pass, call, obj) {
+				return true
+			}
+			if flatIdx >= len(call.Args) {
+				safe = false // multi-value call like f(g()); can't edit this argument
+				return false
+			}
+			if hasSideEffects(call.Args[flatIdx]) {
+				safe = false
+				return false
+			}
+			starts := make([]token.Pos, len(call.Args))
+			ends := make([]token.Pos, len(call.Args))
+			for j, arg := range call.Args {
+				starts[j], ends[j] = arg.Pos(), arg.End()
+			}
+			edits = append(edits, deleteListItem(starts, ends, flatIdx))
+			return true
+		})
+	}
+	if !safe {
+		return nil, false
+	}
+	return edits, true
+}
+
+func callsFunc(pass *analysis.Pass, call *ast.CallExpr, obj types.Object) bool {
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		return pass.TypesInfo.Uses[fun] == obj
+	case *ast.SelectorExpr:
+		return pass.TypesInfo.Uses[fun.Sel] == obj
+	}
+	return false
+}
+
+// funcEscapesAsValue reports whether obj (a function or method object)
+// is referenced anywhere in files other than as the callee of a direct
+// call, e.g. assigned to a variable or field, captured as a method
+// value, or passed or returned as a value. infoFor supplies the
+// *types.Info covering each file, since per-package and whole-program
+// callers each resolve that differently. Callers that only find call
+// sites by matching call.Fun against obj would miss a call reached
+// through such a value, leaving its argument count stale once the
+// parameter is deleted from the declaration.
+func funcEscapesAsValue(files []*ast.File, infoFor func(*ast.File) *types.Info, obj types.Object) bool {
+	for _, file := range files {
+		info := infoFor(file)
+		if info == nil {
+			continue
+		}
+		var stack []ast.Node
+		escapes := false
+		ast.Inspect(file, func(n ast.Node) bool {
+			if n == nil {
+				stack = stack[:len(stack)-1]
+				return false
+			}
+			if escapes {
+				return false
+			}
+			if id, ok := n.(*ast.Ident); ok && info.Uses[id] == obj && !isDirectCallTarget(stack, id) {
+				escapes = true
+				return false
+			}
+			stack = append(stack, n)
+			return true
+		})
+		if escapes {
+			return true
+		}
+	}
+	return false
+}
+
+// isDirectCallTarget reports whether n, whose ancestor chain within
+// the current AST walk is stack, is the function being called in a
+// *ast.CallExpr: either n itself is call.Fun, or n is the Sel of a
+// *ast.SelectorExpr that is call.Fun.
+func isDirectCallTarget(stack []ast.Node, n ast.Node) bool {
+	if len(stack) == 0 {
+		return false
+	}
+	switch parent := stack[len(stack)-1].(type) {
+	case *ast.CallExpr:
+		return parent.Fun == n
+	case *ast.SelectorExpr:
+		if len(stack) < 2 {
+			return false
+		}
+		grand, ok := stack[len(stack)-2].(*ast.CallExpr)
+		return ok && grand.Fun == parent
+	}
+	return false
+}
+
+// hasSideEffects reports whether evaluating e could have an effect the
+// program depends on, beyond producing its value: a call (which might
+// panic or mutate state) or a channel receive.
+func hasSideEffects(e ast.Expr) bool {
+	has := false
+	ast.Inspect(e, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.CallExpr:
+			has = true
+		case *ast.UnaryExpr:
+			if x.Op == token.ARROW {
+				has = true
+			}
+		}
+		return !has
+	})
+	return has
+}
+
+// deleteListItem returns the edit needed to remove the idx'th item of
+// a comma-separated list, given the positions of every item in it. It
+// swallows the trailing comma, or the leading one if idx is last.
+func deleteListItem(starts, ends []token.Pos, idx int) analysis.TextEdit {
+	if len(starts) == 1 {
+		return analysis.TextEdit{Pos: starts[0], End: ends[0]}
+	}
+	if idx < len(starts)-1 {
+		return analysis.TextEdit{Pos: starts[idx], End: starts[idx+1]}
+	}
+	return analysis.TextEdit{Pos: ends[idx-1], End: ends[idx]}
+}
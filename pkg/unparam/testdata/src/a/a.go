@@ -0,0 +1,10 @@
+package a // want package:"signatures"
+
+func used(x int) int {
+	return x + 1
+}
+
+func unused(x int) int { // want `x is unused`
+	y := 2
+	return y + 3
+}
@@ -0,0 +1,10 @@
+package b // want package:"signatures"
+
+func helper(unused int) int { // want `unused is unused`
+	y := 2
+	return y + 3
+}
+
+func Caller() int {
+	return helper(5)
+}
@@ -0,0 +1,12 @@
+package c // want package:"signatures"
+
+func helper(unused int) int { // want `unused is unused`
+	y := 2
+	return y + 3
+}
+
+var g = helper
+
+func Caller() int {
+	return g(5)
+}
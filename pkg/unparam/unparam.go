@@ -0,0 +1,256 @@
+// Copyright (c) 2017, Daniel Martí <mvdan@mvdan.cc>
+// See LICENSE for licensing information
+
+// Package unparam implements the unparam analysis as a *analysis.Analyzer,
+// so that it can be embedded in other tools such as golangci-lint or gopls
+// instead of only being run as a standalone command.
+package unparam
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/types"
+	"regexp"
+	"sort"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/buildssa"
+	"golang.org/x/tools/go/ssa"
+)
+
+const doc = `check for unused function parameters
+
+unparam reports parameters which are never used within the bodies of
+the functions that declare them, ignoring any parameter required to
+satisfy an interface or a function-typed field or variable elsewhere
+in the package.`
+
+// Analyzer reports unused function parameters.
+var Analyzer = &analysis.Analyzer{
+	Name:      "unparam",
+	Doc:       doc,
+	Run:       run,
+	Requires:  []*analysis.Analyzer{buildssa.Analyzer},
+	FactTypes: []analysis.Fact{new(signaturesFact)},
+}
+
+// signaturesFact records, for a single package, the set of function
+// signatures (ignoring parameter and result names) that are required
+// somewhere in that package or one of its dependencies, either because
+// they satisfy an interface method or because they're assigned to a
+// function-typed struct field or variable.
+//
+// Facts only flow from a package to the packages that import it, so a
+// function can still be misreported as unused if the only thing that
+// requires its exact signature lives in a package that imports it,
+// rather than one it imports; the whole-program callgraph-based pass
+// added alongside the always-const-arg and unused-result checks closes
+// that gap by seeing every call site regardless of import direction.
+type signaturesFact struct {
+	Sigs []string
+}
+
+func (*signaturesFact) AFact() {}
+
+func (f *signaturesFact) String() string {
+	return "signatures"
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	ssainput := pass.ResultOf[buildssa.Analyzer].(*buildssa.SSA)
+
+	rules, err := passConfig(pass)
+	if err != nil {
+		return nil, err
+	}
+
+	funcSigns := collectFuncSigns(pass)
+
+	var potential []*ssa.Parameter
+	for _, fn := range ssainput.SrcFuncs {
+		if len(fn.Blocks) == 0 { // stub
+			continue
+		}
+		if isDummyImpl(rules, fn.Blocks[0]) { // panic implementation
+			continue
+		}
+		for i, par := range fn.Params {
+			if i == 0 && fn.Signature.Recv() != nil { // receiver
+				continue
+			}
+			switch par.Object().Name() {
+			case "", "_": // unnamed
+				continue
+			}
+			if len(*par.Referrers()) > 0 { // used
+				continue
+			}
+			potential = append(potential, par)
+		}
+	}
+	sort.Slice(potential, func(i, j int) bool {
+		return potential[i].Pos() < potential[j].Pos()
+	})
+	for _, par := range potential {
+		sign := par.Parent().Signature
+		if funcSigns[signString(sign)] { // could be required
+			continue
+		}
+		if rules.excluded(fullFuncName(par.Parent())) {
+			continue
+		}
+		if rules.allowsUnused(pass.Pkg.Path(), par.Name()) {
+			continue
+		}
+		if decl, ok := par.Parent().Syntax().(*ast.FuncDecl); ok && lintIgnored(decl.Doc, "unparam") {
+			continue
+		}
+		diag := analysis.Diagnostic{
+			Pos:      par.Pos(),
+			Category: "unparam",
+			Message:  par.Name() + " is unused",
+		}
+		if fix := paramFix(pass, par); fix != nil {
+			diag.SuggestedFixes = []analysis.SuggestedFix{*fix}
+		}
+		pass.Report(diag)
+	}
+	return nil, nil
+}
+
+// fullFuncName names fn the way Rules.Exclude patterns match against:
+// "pkg.Func" for a plain function, "(pkg.Type).Method" for a method.
+func fullFuncName(fn *ssa.Function) string {
+	if recv := fn.Signature.Recv(); recv != nil {
+		typ := recv.Type()
+		if p, ok := typ.(*types.Pointer); ok {
+			typ = p.Elem()
+		}
+		if named, ok := typ.(*types.Named); ok {
+			return fmt.Sprintf("(%s.%s).%s", named.Obj().Pkg().Name(), named.Obj().Name(), fn.Name())
+		}
+	}
+	if fn.Pkg != nil {
+		return fn.Pkg.Pkg.Name() + "." + fn.Name()
+	}
+	return fn.Name()
+}
+
+// collectFuncSigns gathers the set of signatures required by the
+// current package, merges in the signatures its dependencies already
+// found to be required, and re-exports the union so that packages
+// importing this one see the same information.
+func collectFuncSigns(pass *analysis.Pass) map[string]bool {
+	funcSigns := make(map[string]bool)
+	addSign := func(t types.Type) {
+		sign, ok := t.(*types.Signature)
+		if !ok || sign.Params().Len() == 0 {
+			return
+		}
+		funcSigns[signString(sign)] = true
+	}
+	scope := pass.Pkg.Scope()
+	for _, name := range scope.Names() {
+		switch obj := scope.Lookup(name).(type) {
+		case *types.Func:
+			params := obj.Type().(*types.Signature).Params()
+			for i := 0; i < params.Len(); i++ {
+				addSign(params.At(i).Type())
+			}
+		case *types.TypeName:
+			switch x := obj.Type().Underlying().(type) {
+			case *types.Struct:
+				for i := 0; i < x.NumFields(); i++ {
+					addSign(x.Field(i).Type())
+				}
+			case *types.Interface:
+				for i := 0; i < x.NumMethods(); i++ {
+					addSign(x.Method(i).Type())
+				}
+			case *types.Signature:
+				addSign(x)
+			}
+		}
+	}
+	for _, imp := range pass.Pkg.Imports() {
+		var fact signaturesFact
+		if pass.ImportPackageFact(imp, &fact) {
+			for _, s := range fact.Sigs {
+				funcSigns[s] = true
+			}
+		}
+	}
+	sigs := make([]string, 0, len(funcSigns))
+	for s := range funcSigns {
+		sigs = append(sigs, s)
+	}
+	sort.Strings(sigs)
+	pass.ExportPackageFact(&signaturesFact{Sigs: sigs})
+	return funcSigns
+}
+
+var rxHarmlessCall = regexp.MustCompile(`(?i)\blog(ger)?\b|\bf?print`)
+
+// isDummyImpl is dummyImpl with the dummy-impl-detection check toggle
+// and the configured harmless-call regex applied.
+func isDummyImpl(rules *Rules, blk *ssa.BasicBlock) bool {
+	if !rules.checkEnabled(checkDummyImpl) {
+		return false
+	}
+	return dummyImpl(blk, rules.harmlessCallRegex())
+}
+
+// dummyImpl reports whether a block is a dummy implementation. This is
+// true if the block will almost immediately panic, throw or return
+// constants only.
+func dummyImpl(blk *ssa.BasicBlock, rxHarmless *regexp.Regexp) bool {
+	for _, instr := range blk.Instrs {
+		switch x := instr.(type) {
+		case *ssa.Alloc, *ssa.Store, *ssa.UnOp, *ssa.BinOp,
+			*ssa.MakeInterface, *ssa.MakeMap, *ssa.Extract,
+			*ssa.IndexAddr, *ssa.FieldAddr, *ssa.Slice,
+			*ssa.Lookup, *ssa.ChangeType, *ssa.TypeAssert,
+			*ssa.Convert, *ssa.ChangeInterface:
+			// non-trivial expressions in panic/log/print
+			// calls
+		case *ssa.Return:
+			for _, val := range x.Results {
+				if _, ok := val.(*ssa.Const); !ok {
+					return false
+				}
+			}
+			return true
+		case *ssa.Panic:
+			return true
+		case *ssa.Call:
+			if rxHarmless.MatchString(x.Call.Value.String()) {
+				continue
+			}
+			return x.Call.Value.Name() == "throw" // runtime's panic
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// signString is similar to Signature.String(), but it ignores
+// param/result names.
+func signString(sign *types.Signature) string {
+	var buf bytes.Buffer
+	tupleJoin(&buf, sign.Params())
+	tupleJoin(&buf, sign.Results())
+	return buf.String()
+}
+
+func tupleJoin(buf *bytes.Buffer, t *types.Tuple) {
+	buf.WriteByte('(')
+	for i := 0; i < t.Len(); i++ {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(t.At(i).Type().String())
+	}
+	buf.WriteByte(')')
+}
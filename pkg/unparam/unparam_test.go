@@ -0,0 +1,29 @@
+// Copyright (c) 2017, Daniel Martí <mvdan@mvdan.cc>
+// See LICENSE for licensing information
+
+package unparam_test
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+
+	"github.com/chrispat/unparam/pkg/unparam"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), unparam.Analyzer, "a")
+}
+
+func TestAnalyzerSuggestedFix(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(t, analysistest.TestData(), unparam.Analyzer, "b")
+}
+
+// TestAnalyzerSuggestedFixEscapesAsValue checks that no fix is offered
+// when the function is called through a value (here, a package-level
+// variable holding it) rather than only by name: callSiteEdits has no
+// way to find that call site, so rewriting the declaration would leave
+// it with a stale argument count.
+func TestAnalyzerSuggestedFixEscapesAsValue(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(t, analysistest.TestData(), unparam.Analyzer, "c")
+}
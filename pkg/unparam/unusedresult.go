@@ -0,0 +1,130 @@
+// Copyright (c) 2017, Daniel Martí <mvdan@mvdan.cc>
+// See LICENSE for licensing information
+
+package unparam
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"sort"
+
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/callgraph/cha"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+var errorType = types.Universe.Lookup("error").Type()
+
+// UnusedResults reports result values that no caller, anywhere in the
+// program's callgraph, ever consumes. It mirrors the unused-parameter
+// check but looks at call sites instead of function bodies: a result is
+// unused when every *ssa.Call reaching the function either discards it
+// outright (a single-value result with no referrers) or never extracts
+// it from the returned tuple at all.
+func UnusedResults(cfg Config, args ...string) ([]string, error) {
+	wp, err := cfg.loadWholeProgram(args...)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := cwdConfig()
+	if err != nil {
+		return nil, err
+	}
+	if !rules.checkEnabled(checkUnusedResult) {
+		return nil, nil
+	}
+	funcSigns := computeFuncSigns(wp.prog)
+	cg := cha.CallGraph(wp.prog)
+	cg.DeleteSyntheticNodes()
+
+	var warns []string
+	for fn := range ssautil.AllFunctions(wp.prog) {
+		if fn.Pkg == nil || !wp.wantPkg[fn.Pkg.Pkg] {
+			continue
+		}
+		if len(fn.Blocks) == 0 {
+			continue
+		}
+		if isDummyImpl(rules, fn.Blocks[0]) { // stub returning constants; not worth flagging
+			continue
+		}
+		results := fn.Signature.Results()
+		if results.Len() == 0 {
+			continue
+		}
+		if funcSigns[signString(fn.Signature)] { // required shape; callers can't be changed freely
+			continue
+		}
+		if fn.Object() != nil && fn.Object().Exported() {
+			continue // exported API: callers outside this program are invisible to us
+		}
+		if rules.excluded(fullFuncName(fn)) {
+			continue
+		}
+		if decl, ok := fn.Syntax().(*ast.FuncDecl); ok && lintIgnored(decl.Doc, "unparam:"+checkUnusedResult) {
+			continue
+		}
+		node := cg.Nodes[fn]
+		if node == nil {
+			continue
+		}
+		calls := callSites(node)
+		if len(calls) == 0 {
+			continue // never called directly, or only via go/defer which discards by design
+		}
+		for idx := 0; idx < results.Len(); idx++ {
+			res := results.At(idx)
+			if rules.checkEnabled(checkSkipErrorResult) && isErrorType(res.Type()) {
+				continue // discarding errors is common and intentional
+			}
+			if resultUsedSomewhere(calls, results.Len(), idx) {
+				continue
+			}
+			pos := wp.fset.Position(res.Pos())
+			if res.Name() != "" {
+				warns = append(warns, fmt.Sprintf("%s: result %s is never used", pos, res.Name()))
+			} else {
+				warns = append(warns, fmt.Sprintf("%s: result %d is never used", pos, idx))
+			}
+		}
+	}
+	sort.Strings(warns)
+	return warns, nil
+}
+
+func callSites(node *callgraph.Node) []*ssa.Call {
+	var calls []*ssa.Call
+	for _, edge := range node.In {
+		if call, ok := edge.Site.(*ssa.Call); ok {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+func resultUsedSomewhere(calls []*ssa.Call, numResults, idx int) bool {
+	for _, call := range calls {
+		if numResults == 1 {
+			if len(*call.Referrers()) > 0 {
+				return true
+			}
+			continue
+		}
+		for _, instr := range *call.Referrers() {
+			ext, ok := instr.(*ssa.Extract)
+			if !ok || ext.Index != idx {
+				continue
+			}
+			if len(*ext.Referrers()) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isErrorType(t types.Type) bool {
+	return types.Identical(t, errorType)
+}
@@ -0,0 +1,253 @@
+// Copyright (c) 2017, Daniel Martí <mvdan@mvdan.cc>
+// See LICENSE for licensing information
+
+package unparam
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFixture lays out files (keyed by path relative to the module
+// root) under a fresh temp dir with its own go.mod, so the whole-program
+// checks can load it as a self-contained module via go/packages.
+func writeFixture(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module fixture\n\ngo 1.18\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for name, src := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// chdir switches the working directory to dir for the duration of the
+// test, restoring it on cleanup. The whole-program checks load their
+// config via cwdConfig, which is rooted at the process's working
+// directory.
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := os.Chdir(wd); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestConstArgs(t *testing.T) {
+	dir := writeFixture(t, map[string]string{
+		"p/p.go": `package p
+
+func helper(n int) int {
+	return n * 2
+}
+
+func Caller() int {
+	return helper(3) + helper(3)
+}
+`,
+	})
+	chdir(t, dir)
+
+	warns, err := ConstArgs(Config{}, "./p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warns) != 1 {
+		t.Fatalf("got %d warnings, want 1: %v", len(warns), warns)
+	}
+	const want = "parameter n always receives 3"
+	if got := warns[0]; !strings.Contains(got, want) {
+		t.Errorf("warning %q doesn't contain %q", got, want)
+	}
+}
+
+func TestUnusedResults(t *testing.T) {
+	dir := writeFixture(t, map[string]string{
+		"p/p.go": `package p
+
+func helper() (int, error) {
+	x := 1
+	return x + 1, nil
+}
+
+func Caller() {
+	helper()
+}
+`,
+	})
+	chdir(t, dir)
+
+	warns, err := UnusedResults(Config{}, "./p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warns) != 1 {
+		t.Fatalf("got %d warnings, want 1: %v", len(warns), warns)
+	}
+	const want = "result 0 is never used"
+	if got := warns[0]; !strings.Contains(got, want) {
+		t.Errorf("warning %q doesn't contain %q", got, want)
+	}
+}
+
+func TestFix(t *testing.T) {
+	dir := writeFixture(t, map[string]string{
+		"p/p.go": `package p
+
+func helper(unused int) int {
+	y := 2
+	return y + 3
+}
+
+func Caller() int {
+	return helper(2)
+}
+`,
+	})
+	chdir(t, dir)
+
+	changed, err := Fix(Config{}, "./p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 1 {
+		t.Fatalf("got %d changed files, want 1: %v", len(changed), changed)
+	}
+
+	const want = `package p
+
+func helper() int {
+	y := 2
+	return y + 3
+}
+
+func Caller() int {
+	return helper()
+}
+`
+	got, err := os.ReadFile(filepath.Join(dir, "p", "p.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("fixed source:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// TestFixSkipsFuncValue checks that Fix leaves helper alone when it's
+// called through a package-level variable rather than only by name:
+// wholeProgramCallSiteEdits can't find that call site by matching the
+// static callee, so rewriting helper's signature would leave the call
+// through g with a stale argument count.
+func TestFixSkipsFuncValue(t *testing.T) {
+	const src = `package p
+
+func helper(unused int) int {
+	y := 2
+	return y + 3
+}
+
+var g = helper
+
+func Caller() int {
+	return g(2)
+}
+`
+	dir := writeFixture(t, map[string]string{"p/p.go": src})
+	chdir(t, dir)
+
+	changed, err := Fix(Config{}, "./p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 0 {
+		t.Fatalf("got %d changed files, want 0: %v", len(changed), changed)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "p", "p.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != src {
+		t.Errorf("source was modified despite the unsafe call site:\n%s", got)
+	}
+}
+
+// TestFixSkipsMethodValue checks the same as TestFixSkipsFuncValue, but
+// for a method captured as a method value: SSA represents that call
+// through a synthetic bound-method wrapper rather than a direct static
+// call to the method, so it needs the same escape check to catch it.
+func TestFixSkipsMethodValue(t *testing.T) {
+	const src = `package p
+
+type T struct{}
+
+func (T) method(unused int) int {
+	y := 2
+	return y + 3
+}
+
+func Caller() int {
+	var t T
+	m := t.method
+	return m(2)
+}
+`
+	dir := writeFixture(t, map[string]string{"p/p.go": src})
+	chdir(t, dir)
+
+	changed, err := Fix(Config{}, "./p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 0 {
+		t.Fatalf("got %d changed files, want 0: %v", len(changed), changed)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "p", "p.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != src {
+		t.Errorf("source was modified despite the unsafe call site:\n%s", got)
+	}
+}
+
+// TestFixSkipsExported checks that Fix doesn't rewrite an exported
+// function's signature, since callers outside the loaded program can't
+// be seen.
+func TestFixSkipsExported(t *testing.T) {
+	const src = `package p
+
+func Helper(unused int) int {
+	y := 2
+	return y + 3
+}
+`
+	dir := writeFixture(t, map[string]string{"p/p.go": src})
+	chdir(t, dir)
+
+	changed, err := Fix(Config{}, "./p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 0 {
+		t.Fatalf("got %d changed files, want 0: %v", len(changed), changed)
+	}
+}